@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestNewVaultAuthenticatorSelectsByMethod(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     VaultAuthConfig
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "empty method defaults to token", cfg: VaultAuthConfig{}, want: &tokenAuthenticator{}},
+		{name: "token", cfg: VaultAuthConfig{Method: VaultAuthToken, Token: "t"}, want: &tokenAuthenticator{token: "t"}},
+		{
+			name: "approle",
+			cfg:  VaultAuthConfig{Method: VaultAuthAppRole, RoleID: "r", SecretID: "s"},
+			want: &appRoleAuthenticator{roleID: "r", secretID: "s"},
+		},
+		{name: "approle missing secret_id", cfg: VaultAuthConfig{Method: VaultAuthAppRole, RoleID: "r"}, wantErr: true},
+		{
+			name: "kubernetes",
+			cfg:  VaultAuthConfig{Method: VaultAuthKubernetes, K8sRole: "k8s-role"},
+			want: &kubernetesAuthenticator{role: "k8s-role"},
+		},
+		{name: "kubernetes missing role", cfg: VaultAuthConfig{Method: VaultAuthKubernetes}, wantErr: true},
+		{name: "unknown method", cfg: VaultAuthConfig{Method: "bogus"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			auth, err := NewVaultAuthenticator(c.cfg)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			switch want := c.want.(type) {
+			case *tokenAuthenticator:
+				got, ok := auth.(*tokenAuthenticator)
+				if !ok || got.token != want.token {
+					t.Errorf("got %#v, want %#v", auth, want)
+				}
+			case *appRoleAuthenticator:
+				got, ok := auth.(*appRoleAuthenticator)
+				if !ok || got.roleID != want.roleID || got.secretID != want.secretID {
+					t.Errorf("got %#v, want %#v", auth, want)
+				}
+			case *kubernetesAuthenticator:
+				got, ok := auth.(*kubernetesAuthenticator)
+				if !ok || got.role != want.role {
+					t.Errorf("got %#v, want %#v", auth, want)
+				}
+			}
+		})
+	}
+}
@@ -9,8 +9,6 @@ import (
 
 	"github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
-
-	vaultapi "github.com/hashicorp/vault/api"
 )
 
 var (
@@ -42,28 +40,6 @@ type SSLConfig struct {
 	CaCert  string
 }
 
-// GetVaultSecret - Pull a Secret From Vault given a path
-func GetVaultSecret(path string) (*vaultapi.Secret, error) {
-	// Get Config Completely From Environment
-	var c *vaultapi.Config
-
-	vault, err := vaultapi.NewClient(c)
-
-	if err != nil {
-		return nil, fmt.Errorf("Vault - Client Error: %s", err)
-	}
-
-	vaultSecret, err := vault.Logical().Read(path)
-
-	if err != nil {
-		return nil, fmt.Errorf("Vault - Read Error: %s", err)
-	}
-	if vaultSecret == nil {
-		return nil, fmt.Errorf("Vault - No secret at path: %s", path)
-	}
-	return vaultSecret, nil
-}
-
 func main() {
 	app := cli.NewApp()
 
@@ -71,33 +47,9 @@ func main() {
 	var dc string
 	var varsFile string
 	var mlockBool = false
-
-	type EnvVars map[string]string
-
-	type Secrets map[string]string
-
-	type ConfigV1 struct {
-		Vars         EnvVars
-		Secrets      Secrets
-		Environments map[string]struct {
-			Vars    EnvVars
-			Secrets Secrets
-			Dcs     map[string]EnvVars
-		}
-	}
-
-	type Config struct {
-		Vars         EnvVars
-		Secrets      Secrets
-		Environments map[string]struct {
-			Vars    EnvVars
-			Secrets Secrets
-			Dcs     map[string]struct {
-				Vars    EnvVars
-				Secrets Secrets
-			}
-		}
-	}
+	var formatFlag string
+	var concurrency int
+	var failFast bool
 
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
@@ -125,11 +77,66 @@ func main() {
 			Required:    false,
 			Destination: &mlockBool,
 		},
+		cli.StringFlag{
+			Name:   "vault-auth-method",
+			Value:  VaultAuthToken,
+			Usage:  "Vault auth method to use (token, approle, kubernetes)",
+			EnvVar: "VAULT_AUTH_METHOD",
+		},
+		cli.StringFlag{
+			Name:   "vault-token",
+			Usage:  "Vault token (token auth method)",
+			EnvVar: "VAULT_TOKEN",
+		},
+		cli.StringFlag{
+			Name:   "vault-role-id",
+			Usage:  "Vault AppRole role_id (approle auth method)",
+			EnvVar: "VAULT_ROLE_ID",
+		},
+		cli.StringFlag{
+			Name:   "vault-secret-id",
+			Usage:  "Vault AppRole secret_id (approle auth method)",
+			EnvVar: "VAULT_SECRET_ID",
+		},
+		cli.StringFlag{
+			Name:   "vault-k8s-role",
+			Usage:  "Vault role to authenticate as (kubernetes auth method)",
+			EnvVar: "VAULT_K8S_ROLE",
+		},
+		cli.StringFlag{
+			Name:   "kv-version",
+			Value:  "auto",
+			Usage:  "Vault KV engine version to assume (auto, 1, 2)",
+			EnvVar: "VAULT_KV_VERSION",
+		},
+		cli.StringFlag{
+			Name:        "format",
+			Value:       "export",
+			Usage:       "Output format: export, dotenv, json, hcl (ignored when a command follows --)",
+			EnvVar:      "FORMAT",
+			Destination: &formatFlag,
+		},
+		cli.IntFlag{
+			Name:        "concurrency",
+			Value:       8,
+			Usage:       "Number of secrets to resolve from Vault at once",
+			EnvVar:      "CONCURRENCY",
+			Destination: &concurrency,
+		},
+		cli.BoolFlag{
+			Name:        "fail-fast",
+			Usage:       "Abort on the first Vault error instead of aggregating every failure",
+			Destination: &failFast,
+		},
 	}
 
 	app.Version = version
 	app.Name = "buildenv"
 	app.Usage = "Get the Build Environment from a settings yaml file."
+	app.Commands = []cli.Command{
+		ExportCommand,
+		ImportCommand,
+	}
 
 	app.Action = func(c *cli.Context) error {
 
@@ -139,6 +146,20 @@ func main() {
 			return cli.NewExitError("environment is required", EnvErrorCode)
 		}
 
+		// The Vault client is authenticated lazily, on first secret lookup, and
+		// reused for every subsequent path so we only log in once per run.
+		var vault *VaultClient
+		getVault := func() (*VaultClient, error) {
+			if vault == nil {
+				v, err := NewVaultClientFromContext(c)
+				if err != nil {
+					return nil, err
+				}
+				vault = v
+			}
+			return vault, nil
+		}
+
 		filename, _ := filepath.Abs(varsFile)
 		yamlFile, err := ioutil.ReadFile(filename)
 
@@ -162,68 +183,40 @@ func main() {
 			}
 		}
 
-		fmt.Println("# Setting Variables for:")
-		fmt.Printf("# Environment: %s\n", env)
-		if dc != "" {
-			fmt.Printf("# Datacenter: %s\n", dc)
-		}
-
-		// Print The Globals
-		fmt.Println("# Global Vars:")
-		for k, v := range config.Vars {
-			fmt.Printf("export %s=%q\n", k, v)
+		resolved, err := ResolveConfig(getVault, config, configV1, legacy, env, dc, concurrency, failFast)
+		if err != nil {
+			return cli.NewExitError(err.Error(), VaultErrorCode)
 		}
 
-		fmt.Println("# Global Secrets:")
-		for k, path := range config.Secrets {
-			secret, err := GetVaultSecret(path)
-			if err == nil {
-				fmt.Printf("export %s=%q # %s\n", k, secret.Data["value"], path)
-			} else {
-				return cli.NewExitError(err.Error(), VaultErrorCode)
-			}
+		rendered, err := RenderTemplates(resolved, getVault)
+		if err != nil {
+			return cli.NewExitError(err.Error(), VaultErrorCode)
 		}
 
-		// Print The Environment Specific Vars
-		fmt.Printf("# Environment (%s) Vars:\n", env)
-		for k, v := range config.Environments[env].Vars {
-			fmt.Printf("export %s=%q\n", k, v)
+		// `buildenv -e prod -- myapp --flag` resolves into the child's
+		// environment directly, so secrets are never written to stdout.
+		if args := []string(c.Args()); len(args) > 0 {
+			return ExecInto(args, rendered)
 		}
 
-		fmt.Printf("# Environment (%s) Secrets:\n", env)
-		for k, path := range config.Environments[env].Secrets {
-			secret, err := GetVaultSecret(path)
-			if err == nil {
-				fmt.Printf("export %s=%q # %s\n", k, secret.Data["value"], path)
-			} else {
-				return cli.NewExitError(err.Error(), VaultErrorCode)
-			}
+		formatter, err := NewFormatter(formatFlag)
+		if err != nil {
+			return cli.NewExitError(err.Error(), EnvErrorCode)
 		}
 
-		// Print the DC Specific Vars
-		if legacy {
+		if formatFlag == "" || formatFlag == "export" {
+			fmt.Println("# Setting Variables for:")
+			fmt.Printf("# Environment: %s\n", env)
 			if dc != "" {
-				fmt.Printf("# Datacenter (%s) Specific Vars:\n", dc)
-				for k, v := range config.Environments[env].Dcs[dc].Vars {
-					fmt.Printf("export %s=%q\n", k, v)
-				}
-			}
-		} else {
-			fmt.Printf("# Datacenter (%s) Specific Vars:\n", env)
-			for k, v := range config.Environments[env].Dcs[dc].Vars {
-				fmt.Printf("export %s=%q\n", k, v)
+				fmt.Printf("# Datacenter: %s\n", dc)
 			}
+		}
 
-			fmt.Printf("# Datacenter (%s) Specific Secrets:\n", env)
-			for k, path := range config.Environments[env].Dcs[dc].Secrets {
-				secret, err := GetVaultSecret(path)
-				if err == nil {
-					fmt.Printf("export %s=%q # %s\n", k, secret.Data["value"], path)
-				} else {
-					return cli.NewExitError(err.Error(), VaultErrorCode)
-				}
-			}
+		out, err := formatter.Format(rendered)
+		if err != nil {
+			return cli.NewExitError(err.Error(), YamlErrorCode)
 		}
+		fmt.Print(out)
 
 		return nil
 	}
@@ -0,0 +1,34 @@
+package main
+
+// EnvVars is a flat map of environment variable name to literal value.
+type EnvVars map[string]string
+
+// Secrets maps an environment variable name to the Vault secret that
+// supplies its value.
+type Secrets map[string]SecretRef
+
+// ConfigV1 is the legacy variables.yml schema, where datacenter overrides
+// are flat EnvVars with no secrets of their own.
+type ConfigV1 struct {
+	Vars         EnvVars
+	Secrets      Secrets
+	Environments map[string]struct {
+		Vars    EnvVars
+		Secrets Secrets
+		Dcs     map[string]EnvVars
+	}
+}
+
+// Config is the current variables.yml schema.
+type Config struct {
+	Vars         EnvVars
+	Secrets      Secrets
+	Environments map[string]struct {
+		Vars    EnvVars
+		Secrets Secrets
+		Dcs     map[string]struct {
+			Vars    EnvVars
+			Secrets Secrets
+		}
+	}
+}
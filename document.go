@@ -0,0 +1,11 @@
+package main
+
+// vaultDocument is the YAML shape shared by `export` and `import`: a map of
+// Vault path to the key/value secret material stored at that path. `export`
+// walks Vault and writes out exactly what it finds; `import` reads the same
+// shape back and writes it into Vault, so export's own output can be fed
+// straight into import to bootstrap or migrate a namespace. Unlike the
+// Config/variables.yml schema (which references secrets by path+key so
+// values are resolved fresh from Vault at use time), a vaultDocument carries
+// literal secret values and should be treated as sensitive.
+type vaultDocument map[string]map[string]interface{}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// ExecInto replaces the current process with argv, injecting vars into its
+// environment via syscall.Exec. Unlike the export/dotenv/json/hcl
+// formatters, it never writes a secret to stdout, closing the shell-history
+// and logging leak that printing exports creates.
+func ExecInto(argv []string, vars map[string]string) error {
+	if len(argv) == 0 {
+		return fmt.Errorf("exec mode requires a command after --")
+	}
+
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return fmt.Errorf("exec: %s", err)
+	}
+
+	return syscall.Exec(path, argv, mergeEnv(os.Environ(), vars))
+}
+
+// mergeEnv layers vars on top of ambient, an os.Environ()-shaped slice,
+// returning a new envp where vars wins for any name present in both. A
+// child process's getenv() (and the Go runtime's own env lookup) returns the
+// first "KEY=" entry in envp, not the last, so any ambient variable vars
+// overrides must be dropped rather than merely appended after.
+func mergeEnv(ambient []string, vars map[string]string) []string {
+	env := make([]string, 0, len(ambient)+len(vars))
+	for _, kv := range ambient {
+		key := kv
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			key = kv[:i]
+		}
+		if _, overridden := vars[key]; overridden {
+			continue
+		}
+		env = append(env, kv)
+	}
+	for k, v := range vars {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env
+}
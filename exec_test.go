@@ -0,0 +1,34 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestMergeEnvOverridesAmbientValue(t *testing.T) {
+	ambient := []string{"FOO=old", "KEPT=asis"}
+	vars := map[string]string{"FOO": "new"}
+
+	env := mergeEnv(ambient, vars)
+	sort.Strings(env)
+
+	want := []string{"FOO=new", "KEPT=asis"}
+	if len(env) != len(want) {
+		t.Fatalf("mergeEnv = %v, want %v", env, want)
+	}
+	for i, v := range want {
+		if env[i] != v {
+			t.Errorf("mergeEnv[%d] = %q, want %q", i, env[i], v)
+		}
+	}
+}
+
+func TestMergeEnvAddsNewKeys(t *testing.T) {
+	env := mergeEnv([]string{"KEPT=asis"}, map[string]string{"NEW": "value"})
+	sort.Strings(env)
+
+	want := []string{"KEPT=asis", "NEW=value"}
+	if len(env) != len(want) || env[0] != want[0] || env[1] != want[1] {
+		t.Errorf("mergeEnv = %v, want %v", env, want)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// ExportCommand walks one or more Vault paths and writes out every secret
+// found as a vaultDocument, so the output can be fed directly into `import`
+// to bootstrap or migrate a Vault namespace. The written file contains
+// literal secret values and should be handled as sensitive.
+var ExportCommand = cli.Command{
+	Name:  "export",
+	Usage: "Walk Vault paths and write their secret material to a YAML file `import` can read back",
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "paths",
+			Usage: "Vault path(s) to recursively walk",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Value: "vars.yml",
+			Usage: "File to write the generated YAML to (contains literal secret values)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		paths := c.StringSlice("paths")
+		if len(paths) == 0 {
+			return cli.NewExitError("at least one --paths is required", EnvErrorCode)
+		}
+
+		vault, err := NewVaultClientFromContext(c)
+		if err != nil {
+			return cli.NewExitError(err.Error(), VaultErrorCode)
+		}
+
+		doc := vaultDocument{}
+		for _, path := range paths {
+			if err := walkVaultPath(vault, path, doc); err != nil {
+				return cli.NewExitError(err.Error(), VaultErrorCode)
+			}
+		}
+
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("unable to marshal export: %s", err), YamlErrorCode)
+		}
+
+		output := c.String("output")
+		if err := ioutil.WriteFile(output, out, 0644); err != nil {
+			return cli.NewExitError(fmt.Sprintf("unable to write %s: %s", output, err), 4)
+		}
+
+		return nil
+	},
+}
+
+// walkVaultPath recursively lists path, recording every leaf secret's full
+// key/value data into doc under its Vault path.
+func walkVaultPath(vault *VaultClient, path string, doc vaultDocument) error {
+	children, err := vault.ListPath(path)
+	if err != nil {
+		return err
+	}
+
+	if len(children) == 0 {
+		data, err := vault.GetSecretData(path, 0)
+		if err != nil {
+			return err
+		}
+		doc[path] = data
+		return nil
+	}
+
+	for _, child := range children {
+		childPath := strings.TrimSuffix(path, "/") + "/" + strings.TrimSuffix(child, "/")
+		if err := walkVaultPath(vault, childPath, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
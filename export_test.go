@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"gopkg.in/yaml.v2"
+)
+
+// TestExportImportRoundTrip reproduces the workflow `export -o vars.yml` then
+// `import -f vars.yml` is meant to support: walk a path into a vaultDocument,
+// marshal it the way export does, unmarshal it the way import does, and
+// write it back - the written payload must match what was originally read,
+// not be misinterpreted as a single path literally named "secrets".
+func TestExportImportRoundTrip(t *testing.T) {
+	const path = "secret/app"
+	original := map[string]interface{}{"user": "alice", "password": "hunter2"}
+
+	var written map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "LIST":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": original})
+		case r.Method == http.MethodPut:
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			written = body
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = server.URL
+	client, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("vaultapi.NewClient: %s", err)
+	}
+	client.SetToken("test")
+	vault := &VaultClient{
+		client:     client,
+		kvVersion:  1,
+		mountCache: make(map[string]kvMountInfo),
+		dataCache:  make(map[string]map[string]interface{}),
+	}
+
+	exported := vaultDocument{}
+	if err := walkVaultPath(vault, path, exported); err != nil {
+		t.Fatalf("walkVaultPath: %s", err)
+	}
+
+	out, err := yaml.Marshal(exported)
+	if err != nil {
+		t.Fatalf("marshal export doc: %s", err)
+	}
+
+	var imported vaultDocument
+	if err := yaml.Unmarshal(out, &imported); err != nil {
+		t.Fatalf("unmarshal import doc: %s", err)
+	}
+
+	data, ok := imported[path]
+	if !ok {
+		t.Fatalf("import doc has no entry for %s (got keys %v) - export/import schemas disagree", path, mapKeys(imported))
+	}
+	if err := vault.WriteSecret(path, data); err != nil {
+		t.Fatalf("WriteSecret: %s", err)
+	}
+
+	if !reflect.DeepEqual(written, original) {
+		t.Errorf("round-tripped write = %v, want %v", written, original)
+	}
+}
+
+func mapKeys(doc vaultDocument) []string {
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	return keys
+}
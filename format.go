@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Formatter renders a resolved set of environment variables for a specific
+// consumer: a shell, a dotenv-style file, JSON, or HCL.
+type Formatter interface {
+	Format(vars map[string]string) (string, error)
+}
+
+// NewFormatter selects a Formatter implementation by --format name. An empty
+// name falls back to the historical "export" behavior.
+func NewFormatter(name string) (Formatter, error) {
+	switch name {
+	case "", "export":
+		return exportFormatter{}, nil
+	case "dotenv":
+		return dotenvFormatter{}, nil
+	case "json":
+		return jsonFormatter{}, nil
+	case "hcl":
+		return hclFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown --format %q (want export, dotenv, json, hcl)", name)
+	}
+}
+
+// sortedKeys returns vars' keys sorted, so every formatter produces
+// deterministic output across runs.
+func sortedKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// exportFormatter renders POSIX `export KEY="value"` statements, the
+// historical default suitable for `eval "$(buildenv ...)"`.
+type exportFormatter struct{}
+
+func (exportFormatter) Format(vars map[string]string) (string, error) {
+	var buf bytes.Buffer
+	for _, k := range sortedKeys(vars) {
+		fmt.Fprintf(&buf, "export %s=%q\n", k, vars[k])
+	}
+	return buf.String(), nil
+}
+
+// dotenvFormatter renders KEY="value" lines, suitable for Docker's
+// --env-file or systemd's EnvironmentFile=. Values are quoted (the same as
+// exportFormatter/hclFormatter) so a secret containing a newline or a quote
+// can't inject bogus extra KEY=VALUE lines into the file.
+type dotenvFormatter struct{}
+
+func (dotenvFormatter) Format(vars map[string]string) (string, error) {
+	var buf bytes.Buffer
+	for _, k := range sortedKeys(vars) {
+		fmt.Fprintf(&buf, "%s=%q\n", k, vars[k])
+	}
+	return buf.String(), nil
+}
+
+// jsonFormatter renders vars as a flat JSON object.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(vars map[string]string) (string, error) {
+	out, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
+
+// hclFormatter renders vars as top-level HCL attributes.
+type hclFormatter struct{}
+
+func (hclFormatter) Format(vars map[string]string) (string, error) {
+	var buf bytes.Buffer
+	for _, k := range sortedKeys(vars) {
+		fmt.Fprintf(&buf, "%s = %q\n", k, vars[k])
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDotenvFormatterEscapesNewlines(t *testing.T) {
+	vars := map[string]string{"SECRET": "line one\nline two"}
+
+	out, err := dotenvFormatter{}.Format(vars)
+	if err != nil {
+		t.Fatalf("Format: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("a newline in the secret produced extra lines: %q", out)
+	}
+	if want := `SECRET="line one\nline two"`; lines[0] != want {
+		t.Errorf("got %q, want %q", lines[0], want)
+	}
+}
+
+func TestExportAndHclFormattersEscapeNewlines(t *testing.T) {
+	vars := map[string]string{"SECRET": "line one\nline two"}
+
+	for name, formatter := range map[string]Formatter{
+		"export": exportFormatter{},
+		"hcl":    hclFormatter{},
+	} {
+		out, err := formatter.Format(vars)
+		if err != nil {
+			t.Fatalf("%s Format: %s", name, err)
+		}
+		lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+		if len(lines) != 1 {
+			t.Errorf("%s: a newline in the secret produced extra lines: %q", name, out)
+		}
+	}
+}
+
+func TestNewFormatterUnknown(t *testing.T) {
+	if _, err := NewFormatter("xml"); err == nil {
+		t.Fatal("expected an error for an unknown --format")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// ImportCommand reads a YAML file containing inline secret material - a map
+// of Vault path to key/value pairs - and writes it into Vault, making it
+// possible to bootstrap or migrate a Vault namespace from a checked-in
+// template.
+var ImportCommand = cli.Command{
+	Name:  "import",
+	Usage: "Write the secret material in a YAML file into Vault",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "variables_file, f",
+			Value: "vars.yml",
+			Usage: "YAML file mapping Vault paths to key/value secret material",
+		},
+		cli.BoolFlag{
+			Name:  "ignore-errors",
+			Usage: "Continue past individual path write failures instead of aborting",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print what would be written without writing to Vault",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		filename := c.String("variables_file")
+		yamlFile, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("unable to read variable file %s", filename), 4)
+		}
+
+		var doc vaultDocument
+		if err := yaml.Unmarshal(yamlFile, &doc); err != nil {
+			return cli.NewExitError("unable to unmarshal yaml", YamlErrorCode)
+		}
+
+		dryRun := c.Bool("dry-run")
+		ignoreErrors := c.Bool("ignore-errors")
+
+		var vault *VaultClient
+		if !dryRun {
+			vault, err = NewVaultClientFromContext(c)
+			if err != nil {
+				return cli.NewExitError(err.Error(), VaultErrorCode)
+			}
+		}
+
+		var failed bool
+		for path, data := range doc {
+			if dryRun {
+				fmt.Printf("# dry-run: would write %d key(s) to %s\n", len(data), path)
+				continue
+			}
+
+			if err := vault.WriteSecret(path, data); err != nil {
+				failed = true
+				fmt.Fprintf(os.Stderr, "import: %s\n", err)
+				if !ignoreErrors {
+					return cli.NewExitError(err.Error(), VaultErrorCode)
+				}
+				continue
+			}
+			fmt.Printf("# wrote %d key(s) to %s\n", len(data), path)
+		}
+
+		if failed {
+			return cli.NewExitError("one or more paths failed to import", VaultErrorCode)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableMlock locks the process's memory into RAM when enabled is set, so
+// secrets resolved from Vault are never swapped to disk.
+func enableMlock(enabled bool) {
+	if !enabled {
+		return
+	}
+
+	if err := unix.Mlockall(unix.MCL_CURRENT | unix.MCL_FUTURE); err != nil {
+		fmt.Fprintf(os.Stderr, "buildenv: mlock failed: %s\n", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// secretJob is one (env var name, SecretRef) pair awaiting resolution.
+type secretJob struct {
+	name string
+	ref  SecretRef
+}
+
+// resolveSecretsConcurrently fetches every job against vault using a bounded
+// pool of concurrency workers, merging all returned env-var/value pairs into
+// one map. When failFast is true, the first error stops any jobs that
+// haven't started yet and is returned alone, matching the historical
+// abort-on-first-error behavior. Otherwise every job runs to completion and
+// every error is aggregated into a single *multierror.Error, so a user sees
+// every broken path from one run instead of fixing them one at a time.
+func resolveSecretsConcurrently(vault *VaultClient, jobs []secretJob, concurrency int, failFast bool) (map[string]string, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobCh := make(chan secretJob)
+	go func() {
+		defer close(jobCh)
+		for _, job := range jobs {
+			select {
+			case jobCh <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	type result struct {
+		values map[string]string
+		err    error
+	}
+	resultCh := make(chan result, len(jobs))
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				values, err := ResolveSecretRef(vault, job.name, job.ref)
+				resultCh <- result{values: values, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	resolved := make(map[string]string)
+	var errs *multierror.Error
+	for r := range resultCh {
+		if r.err != nil {
+			errs = multierror.Append(errs, r.err)
+			if failFast {
+				cancel()
+			}
+			continue
+		}
+		for k, v := range r.values {
+			resolved[k] = v
+		}
+	}
+
+	return resolved, errs.ErrorOrNil()
+}
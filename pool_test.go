@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// newTestVaultClient starts a KV v1-only fake Vault server backing paths, and
+// returns a *VaultClient pointed at it. kvVersion is pinned (not auto) so
+// tests never hit sys/internal/ui/mounts.
+func newTestVaultClient(t *testing.T, paths map[string]map[string]interface{}) *VaultClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path[len("/v1/"):]
+		data, ok := paths[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = server.URL
+	client, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("vaultapi.NewClient: %s", err)
+	}
+	client.SetToken("test")
+
+	return &VaultClient{
+		client:     client,
+		kvVersion:  1,
+		mountCache: make(map[string]kvMountInfo),
+		dataCache:  make(map[string]map[string]interface{}),
+	}
+}
+
+func TestResolveSecretsConcurrentlyAggregatesErrors(t *testing.T) {
+	vault := newTestVaultClient(t, map[string]map[string]interface{}{
+		"secret/good1": {"value": "one"},
+		"secret/good2": {"value": "two"},
+	})
+
+	jobs := []secretJob{
+		{name: "BAD", ref: SecretRef{Path: "secret/missing", Key: "value"}},
+		{name: "GOOD1", ref: SecretRef{Path: "secret/good1", Key: "value"}},
+		{name: "GOOD2", ref: SecretRef{Path: "secret/good2", Key: "value"}},
+	}
+
+	resolved, err := resolveSecretsConcurrently(vault, jobs, 1, false)
+	if err == nil {
+		t.Fatal("expected an aggregated error for the missing secret")
+	}
+	if got, want := resolved["GOOD1"], "one"; got != want {
+		t.Errorf("GOOD1 = %q, want %q", got, want)
+	}
+	if got, want := resolved["GOOD2"], "two"; got != want {
+		t.Errorf("GOOD2 = %q, want %q", got, want)
+	}
+}
+
+func TestResolveSecretsConcurrentlyFailFastStopsRemainingJobs(t *testing.T) {
+	paths := map[string]map[string]interface{}{}
+	jobs := []secretJob{
+		{name: "BAD", ref: SecretRef{Path: "secret/missing", Key: "value"}},
+	}
+	// Queue far more good jobs than the bad one that precedes them; with
+	// concurrency=1 at most one can already be in flight when cancel() runs,
+	// so fail-fast must leave the vast majority unresolved.
+	const tail = 50
+	for i := 0; i < tail; i++ {
+		name := fmt.Sprintf("GOOD%d", i)
+		path := fmt.Sprintf("secret/good%d", i)
+		paths[path] = map[string]interface{}{"value": name}
+		jobs = append(jobs, secretJob{name: name, ref: SecretRef{Path: path, Key: "value"}})
+	}
+	vault := newTestVaultClient(t, paths)
+
+	resolved, err := resolveSecretsConcurrently(vault, jobs, 1, true)
+	if err == nil {
+		t.Fatal("expected an error from the missing secret")
+	}
+	if len(resolved) >= tail {
+		t.Errorf("fail-fast should abort before resolving the whole queued tail, got %d/%d resolved", len(resolved), tail)
+	}
+}
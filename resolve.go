@@ -0,0 +1,71 @@
+package main
+
+import multierror "github.com/hashicorp/go-multierror"
+
+// ResolveConfig merges a Config's (or, for legacy files, a ConfigV1's)
+// global, environment, and datacenter vars and secrets into a single
+// name -> value map, with later sections overriding earlier ones:
+// global -> environment -> datacenter. Secrets are fetched via getVault,
+// which is expected to lazily create and cache a single *VaultClient, using
+// a pool of concurrency workers per section. When failFast is true,
+// resolution stops at the first section that errors; otherwise every
+// section runs and every error is aggregated into one *multierror.Error.
+func ResolveConfig(getVault func() (*VaultClient, error), config Config, configV1 ConfigV1, legacy bool, env, dc string, concurrency int, failFast bool) (map[string]string, error) {
+	resolved := make(map[string]string)
+	var errs *multierror.Error
+
+	mergeVars := func(vars EnvVars) {
+		for k, v := range vars {
+			resolved[k] = v
+		}
+	}
+
+	mergeSecrets := func(secrets Secrets) error {
+		if len(secrets) == 0 {
+			return nil
+		}
+
+		vault, err := getVault()
+		if err != nil {
+			return err
+		}
+
+		jobs := make([]secretJob, 0, len(secrets))
+		for name, ref := range secrets {
+			jobs = append(jobs, secretJob{name: name, ref: ref})
+		}
+
+		values, err := resolveSecretsConcurrently(vault, jobs, concurrency, failFast)
+		for k, v := range values {
+			resolved[k] = v
+		}
+		return err
+	}
+
+	resolveTier := func(vars EnvVars, secrets Secrets) bool {
+		mergeVars(vars)
+		if err := mergeSecrets(secrets); err != nil {
+			errs = multierror.Append(errs, err)
+			return failFast
+		}
+		return false
+	}
+
+	if resolveTier(config.Vars, config.Secrets) {
+		return resolved, errs.ErrorOrNil()
+	}
+
+	if resolveTier(config.Environments[env].Vars, config.Environments[env].Secrets) {
+		return resolved, errs.ErrorOrNil()
+	}
+
+	if legacy {
+		if dc != "" {
+			mergeVars(configV1.Environments[env].Dcs[dc])
+		}
+	} else {
+		resolveTier(config.Environments[env].Dcs[dc].Vars, config.Environments[env].Dcs[dc].Secrets)
+	}
+
+	return resolved, errs.ErrorOrNil()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretRef describes how to resolve one secret entry from variables.yml. It
+// can be written as a plain path string (the historical shorthand, which
+// reads the "value" key), or as an object for KV v2 version pinning and
+// multi-key/wildcard extraction:
+//
+//	db_password: secret/app                               # shorthand
+//	db_password: {path: secret/app, key: password}        # explicit key
+//	db_password: {path: secret/app, key: password, version: 3}
+//	_:           {path: secret/app, all: true, prefix: APP_}
+type SecretRef struct {
+	Path    string
+	Key     string
+	Version int
+	All     bool
+	Prefix  string
+}
+
+// UnmarshalYAML allows a secret entry to be either a bare path string or an
+// object with path/key/version/all/prefix fields.
+func (s *SecretRef) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		s.Path = path
+		s.Key = "value"
+		return nil
+	}
+
+	var alias struct {
+		Path    string
+		Key     string
+		Version int
+		All     bool
+		Prefix  string
+	}
+	if err := unmarshal(&alias); err != nil {
+		return err
+	}
+
+	s.Path = alias.Path
+	s.Key = alias.Key
+	s.Version = alias.Version
+	s.All = alias.All
+	s.Prefix = alias.Prefix
+	if s.Key == "" && !s.All {
+		s.Key = "value"
+	}
+	return nil
+}
+
+// ResolveSecretRef fetches ref from Vault and returns the env-var-name ->
+// value pairs it expands to: a single pair named after name for a normal (or
+// KV v2 pinned) secret, or one pair per key in the secret - named
+// ref.Prefix+KEY - when ref.All is set.
+func ResolveSecretRef(vault *VaultClient, name string, ref SecretRef) (map[string]string, error) {
+	data, err := vault.GetSecretData(ref.Path, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.All {
+		out := make(map[string]string, len(data))
+		for k, v := range data {
+			out[ref.Prefix+strings.ToUpper(k)] = fmt.Sprintf("%v", v)
+		}
+		return out, nil
+	}
+
+	value, ok := data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("Vault - key %q not found at path %s", ref.Key, ref.Path)
+	}
+	return map[string]string{name: fmt.Sprintf("%v", value)}, nil
+}
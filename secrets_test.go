@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestSecretRefUnmarshalYAMLShorthand(t *testing.T) {
+	var ref SecretRef
+	if err := yaml.Unmarshal([]byte(`secret/app`), &ref); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	want := SecretRef{Path: "secret/app", Key: "value"}
+	if ref != want {
+		t.Errorf("got %+v, want %+v", ref, want)
+	}
+}
+
+func TestSecretRefUnmarshalYAMLExplicitKey(t *testing.T) {
+	var ref SecretRef
+	yamlDoc := "path: secret/app\nkey: password\nversion: 3\n"
+	if err := yaml.Unmarshal([]byte(yamlDoc), &ref); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	want := SecretRef{Path: "secret/app", Key: "password", Version: 3}
+	if ref != want {
+		t.Errorf("got %+v, want %+v", ref, want)
+	}
+}
+
+func TestSecretRefUnmarshalYAMLAllDefaultsKeyEmpty(t *testing.T) {
+	var ref SecretRef
+	yamlDoc := "path: secret/app\nall: true\nprefix: APP_\n"
+	if err := yaml.Unmarshal([]byte(yamlDoc), &ref); err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	want := SecretRef{Path: "secret/app", All: true, Prefix: "APP_"}
+	if ref != want {
+		t.Errorf("got %+v, want %+v", ref, want)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/template"
+)
+
+// TemplateFuncs builds the function map exposed to variables.yml values:
+//
+//	vault "path" "key"  - a field from Vault (getVault's client caches reads,
+//	                      so repeated references to the same path within one
+//	                      invocation only hit Vault once)
+//	env "NAME"          - a variable from the process environment
+//	file "path"         - the contents of a local file
+//	base64enc / base64dec - base64 encode/decode a string
+//	default "fallback" value - fallback when value is empty
+func TemplateFuncs(getVault func() (*VaultClient, error)) template.FuncMap {
+	return template.FuncMap{
+		"vault": func(path, key string) (string, error) {
+			vault, err := getVault()
+			if err != nil {
+				return "", err
+			}
+			data, err := vault.GetSecretData(path, 0)
+			if err != nil {
+				return "", err
+			}
+			value, ok := data[key]
+			if !ok {
+				return "", fmt.Errorf("Vault - key %q not found at path %s", key, path)
+			}
+			return fmt.Sprintf("%v", value), nil
+		},
+		"env": os.Getenv,
+		"file": func(path string) (string, error) {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			return string(content), nil
+		},
+		"base64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"base64dec": func(s string) (string, error) {
+			out, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return "", err
+			}
+			return string(out), nil
+		},
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+	}
+}
+
+// RenderTemplates parses and executes every value in vars as a text/template
+// using funcs, exposing the other resolved vars as .env.NAME. Values with no
+// template syntax pass through unchanged.
+func RenderTemplates(vars map[string]string, getVault func() (*VaultClient, error)) (map[string]string, error) {
+	funcs := TemplateFuncs(getVault)
+	data := map[string]interface{}{"env": vars}
+
+	rendered := make(map[string]string, len(vars))
+	for name, value := range vars {
+		tmpl, err := template.New(name).Funcs(funcs).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %s", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("template %s: %s", name, err)
+		}
+		rendered[name] = buf.String()
+	}
+
+	return rendered, nil
+}
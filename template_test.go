@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTemplatesEnvAndDefault(t *testing.T) {
+	os.Setenv("BUILDENV_TEST_RENDER", "from-os-env")
+	defer os.Unsetenv("BUILDENV_TEST_RENDER")
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "secret.txt")
+	if err := ioutil.WriteFile(filePath, []byte("file-contents"), 0600); err != nil {
+		t.Fatalf("write temp file: %s", err)
+	}
+
+	noVault := func() (*VaultClient, error) { return nil, nil }
+
+	vars := map[string]string{
+		"FROM_ENV":     `{{ env "BUILDENV_TEST_RENDER" }}`,
+		"FROM_FILE":    `{{ file "` + filePath + `" }}`,
+		"EMPTY":        "",
+		"WITH_DEFAULT": `{{ default "fallback" .env.EMPTY }}`,
+		"PLAIN":        "no-template-syntax",
+	}
+
+	rendered, err := RenderTemplates(vars, noVault)
+	if err != nil {
+		t.Fatalf("RenderTemplates: %s", err)
+	}
+
+	want := map[string]string{
+		"FROM_ENV":     "from-os-env",
+		"FROM_FILE":    "file-contents",
+		"WITH_DEFAULT": "fallback",
+		"PLAIN":        "no-template-syntax",
+	}
+	for k, v := range want {
+		if rendered[k] != v {
+			t.Errorf("rendered[%q] = %q, want %q", k, rendered[k], v)
+		}
+	}
+}
+
+func TestRenderTemplatesVaultFunc(t *testing.T) {
+	vault := newTestVaultClient(t, map[string]map[string]interface{}{
+		"secret/app": {"password": "hunter2"},
+	})
+	getVault := func() (*VaultClient, error) { return vault, nil }
+
+	rendered, err := RenderTemplates(map[string]string{
+		"DB_PASSWORD": `{{ vault "secret/app" "password" }}`,
+	}, getVault)
+	if err != nil {
+		t.Fatalf("RenderTemplates: %s", err)
+	}
+	if got, want := rendered["DB_PASSWORD"], "hunter2"; got != want {
+		t.Errorf("DB_PASSWORD = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/urfave/cli"
+)
+
+// Vault authentication methods accepted by --vault-auth-method.
+const (
+	VaultAuthToken      = "token"
+	VaultAuthAppRole    = "approle"
+	VaultAuthKubernetes = "kubernetes"
+)
+
+// kubernetesServiceAccountTokenPath is where Kubernetes mounts the pod's
+// ServiceAccount JWT, used for the "kubernetes" auth method.
+const kubernetesServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultAuthenticator obtains a Vault token using a specific login method.
+type VaultAuthenticator interface {
+	Authenticate(client *vaultapi.Client) (string, error)
+}
+
+// VaultAuthConfig holds the flag/env values needed to build a VaultAuthenticator.
+type VaultAuthConfig struct {
+	Method   string
+	Token    string
+	RoleID   string
+	SecretID string
+	K8sRole  string
+}
+
+// NewVaultAuthenticator selects a VaultAuthenticator implementation based on cfg.Method.
+// An empty Method falls back to the historical token-based behavior.
+func NewVaultAuthenticator(cfg VaultAuthConfig) (VaultAuthenticator, error) {
+	switch cfg.Method {
+	case "", VaultAuthToken:
+		return &tokenAuthenticator{token: cfg.Token}, nil
+	case VaultAuthAppRole:
+		if cfg.RoleID == "" || cfg.SecretID == "" {
+			return nil, fmt.Errorf("Vault - AppRole auth requires --vault-role-id and --vault-secret-id")
+		}
+		return &appRoleAuthenticator{roleID: cfg.RoleID, secretID: cfg.SecretID}, nil
+	case VaultAuthKubernetes:
+		if cfg.K8sRole == "" {
+			return nil, fmt.Errorf("Vault - Kubernetes auth requires --vault-k8s-role")
+		}
+		return &kubernetesAuthenticator{role: cfg.K8sRole}, nil
+	default:
+		return nil, fmt.Errorf("Vault - unknown auth method: %s", cfg.Method)
+	}
+}
+
+// tokenAuthenticator uses a pre-existing Vault token, falling back to whatever
+// token the client picked up from VAULT_TOKEN.
+type tokenAuthenticator struct {
+	token string
+}
+
+func (a *tokenAuthenticator) Authenticate(client *vaultapi.Client) (string, error) {
+	if a.token == "" {
+		a.token = client.Token()
+	}
+	if a.token == "" {
+		return "", fmt.Errorf("Vault - no token provided (set VAULT_TOKEN or use --vault-auth-method)")
+	}
+	return a.token, nil
+}
+
+// appRoleAuthenticator logs in via the AppRole auth method.
+type appRoleAuthenticator struct {
+	roleID   string
+	secretID string
+}
+
+func (a *appRoleAuthenticator) Authenticate(client *vaultapi.Client) (string, error) {
+	secret, err := client.Logical().Write("auth/approle/login", map[string]interface{}{
+		"role_id":   a.roleID,
+		"secret_id": a.secretID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Vault - AppRole login error: %s", err)
+	}
+	if secret == nil || secret.Auth == nil {
+		return "", fmt.Errorf("Vault - AppRole login returned no auth information")
+	}
+	return secret.Auth.ClientToken, nil
+}
+
+// kubernetesAuthenticator logs in via the Kubernetes auth method using the
+// pod's mounted ServiceAccount JWT.
+type kubernetesAuthenticator struct {
+	role string
+}
+
+func (a *kubernetesAuthenticator) Authenticate(client *vaultapi.Client) (string, error) {
+	jwt, err := ioutil.ReadFile(kubernetesServiceAccountTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("Vault - unable to read Kubernetes service account token: %s", err)
+	}
+
+	secret, err := client.Logical().Write("auth/kubernetes/login", map[string]interface{}{
+		"role": a.role,
+		"jwt":  string(jwt),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Vault - Kubernetes login error: %s", err)
+	}
+	if secret == nil || secret.Auth == nil {
+		return "", fmt.Errorf("Vault - Kubernetes login returned no auth information")
+	}
+	return secret.Auth.ClientToken, nil
+}
+
+// KVVersionAuto detects each mount's KV version from Vault itself rather than
+// trusting a fixed --kv-version flag.
+const KVVersionAuto = 0
+
+// kvMountInfo records the detected KV engine version and mount point for a
+// path, so GetSecretData only needs to look it up once per mount.
+type kvMountInfo struct {
+	version int
+	mount   string
+}
+
+// VaultClient wraps a single authenticated *vaultapi.Client so repeated
+// secret lookups reuse the same connection and login instead of
+// re-authenticating (or reconnecting) on every call.
+type VaultClient struct {
+	client    *vaultapi.Client
+	kvVersion int // KVVersionAuto, 1, or 2 - from --kv-version
+
+	// cacheMu guards mountCache and dataCache, which are read and written
+	// concurrently by the worker pool in resolveSecretsConcurrently.
+	cacheMu    sync.Mutex
+	mountCache map[string]kvMountInfo
+	dataCache  map[string]map[string]interface{}
+}
+
+// NewVaultClient builds the underlying Vault API client from the standard
+// VAULT_* environment variables and authenticates once using auth, caching
+// the resulting token on the client for the lifetime of the process.
+// kvVersion pins every mount to KV v1 or v2, or KVVersionAuto to detect it
+// per-path via sys/internal/ui/mounts.
+func NewVaultClient(auth VaultAuthenticator, kvVersion int) (*VaultClient, error) {
+	// Get Config Completely From Environment
+	var c *vaultapi.Config
+
+	vault, err := vaultapi.NewClient(c)
+	if err != nil {
+		return nil, fmt.Errorf("Vault - Client Error: %s", err)
+	}
+
+	token, err := auth.Authenticate(vault)
+	if err != nil {
+		return nil, err
+	}
+	vault.SetToken(token)
+
+	return &VaultClient{
+		client:     vault,
+		kvVersion:  kvVersion,
+		mountCache: make(map[string]kvMountInfo),
+		dataCache:  make(map[string]map[string]interface{}),
+	}, nil
+}
+
+// ParseKVVersion turns the --kv-version flag value ("auto", "1", "2") into
+// the int form NewVaultClient expects.
+func ParseKVVersion(s string) (int, error) {
+	switch s {
+	case "", "auto":
+		return KVVersionAuto, nil
+	case "1":
+		return 1, nil
+	case "2":
+		return 2, nil
+	default:
+		return 0, fmt.Errorf("Vault - invalid --kv-version %q (want auto, 1, or 2)", s)
+	}
+}
+
+// kvInfoForPath returns the KV engine version and mount point backing path,
+// consulting --kv-version first and otherwise asking Vault and caching the
+// answer per mount.
+func (v *VaultClient) kvInfoForPath(path string) kvMountInfo {
+	if v.kvVersion != KVVersionAuto {
+		return kvMountInfo{version: v.kvVersion}
+	}
+
+	v.cacheMu.Lock()
+	info, ok := v.mountCache[path]
+	v.cacheMu.Unlock()
+	if ok {
+		return info
+	}
+
+	info = kvMountInfo{version: 1}
+	secret, err := v.client.Logical().Read("sys/internal/ui/mounts/" + path)
+	if err == nil && secret != nil && secret.Data != nil {
+		if options, ok := secret.Data["options"].(map[string]interface{}); ok {
+			if ver, ok := options["version"].(string); ok && ver == "2" {
+				info.version = 2
+			}
+		}
+		if mount, ok := secret.Data["path"].(string); ok {
+			info.mount = strings.TrimSuffix(mount, "/")
+		}
+	}
+
+	v.cacheMu.Lock()
+	v.mountCache[path] = info
+	v.cacheMu.Unlock()
+	return info
+}
+
+// kvDataPath rewrites a KV v2 logical path (mount/sub/path) to its data/
+// equivalent (mount/data/sub/path) so Logical().Read returns the secret
+// instead of its metadata.
+func kvDataPath(path, mount string) string {
+	if mount != "" {
+		return mount + "/data/" + strings.TrimPrefix(path, mount+"/")
+	}
+	// No mount info (e.g. --kv-version=2 without introspection); fall back to
+	// treating the first path segment as the mount point.
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 {
+		return path + "/data"
+	}
+	return parts[0] + "/data/" + parts[1]
+}
+
+// GetSecretData pulls the key/value map for a secret at path, transparently
+// handling KV v2's data/ wrapping. version pins a specific KV v2 version;
+// 0 reads the latest. Results are cached per path+version for the lifetime
+// of the client, so a variables.yml that references the same path multiple
+// times (directly or through template functions) only hits Vault once.
+func (v *VaultClient) GetSecretData(path string, version int) (map[string]interface{}, error) {
+	cacheKey := fmt.Sprintf("%s@%d", path, version)
+	v.cacheMu.Lock()
+	data, ok := v.dataCache[cacheKey]
+	v.cacheMu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	info := v.kvInfoForPath(path)
+
+	readPath := path
+	var params map[string][]string
+	if info.version == 2 {
+		readPath = kvDataPath(path, info.mount)
+		if version > 0 {
+			params = map[string][]string{"version": {strconv.Itoa(version)}}
+		}
+	}
+
+	var vaultSecret *vaultapi.Secret
+	var err error
+	if params != nil {
+		vaultSecret, err = v.client.Logical().ReadWithData(readPath, params)
+	} else {
+		vaultSecret, err = v.client.Logical().Read(readPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Vault - Read Error: %s", err)
+	}
+	if vaultSecret == nil || vaultSecret.Data == nil {
+		return nil, fmt.Errorf("Vault - No secret at path: %s", path)
+	}
+
+	data = vaultSecret.Data
+	if info.version == 2 {
+		unwrapped, ok := vaultSecret.Data["data"].(map[string]interface{})
+		if !ok || unwrapped == nil {
+			return nil, fmt.Errorf("Vault - No secret at path: %s", path)
+		}
+		data = unwrapped
+	}
+
+	v.cacheMu.Lock()
+	v.dataCache[cacheKey] = data
+	v.cacheMu.Unlock()
+	return data, nil
+}
+
+// kvMetadataPath rewrites a KV v2 logical path to its metadata/ equivalent,
+// used for Logical().List since v2's data/ endpoint doesn't support listing.
+func kvMetadataPath(path, mount string) string {
+	if mount != "" {
+		return mount + "/metadata/" + strings.TrimPrefix(path, mount+"/")
+	}
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 {
+		return path + "/metadata"
+	}
+	return parts[0] + "/metadata/" + parts[1]
+}
+
+// ListPath lists the child keys at path, returning nil if path has no
+// children (i.e. it is a leaf secret rather than a directory).
+func (v *VaultClient) ListPath(path string) ([]string, error) {
+	info := v.kvInfoForPath(path)
+
+	listPath := path
+	if info.version == 2 {
+		listPath = kvMetadataPath(path, info.mount)
+	}
+
+	vaultSecret, err := v.client.Logical().List(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("Vault - List Error: %s", err)
+	}
+	if vaultSecret == nil || vaultSecret.Data == nil {
+		return nil, nil
+	}
+
+	raw, ok := vaultSecret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(raw))
+	for _, k := range raw {
+		if s, ok := k.(string); ok {
+			keys = append(keys, s)
+		}
+	}
+	return keys, nil
+}
+
+// WriteSecret writes data to path, wrapping it in the data/ envelope KV v2
+// engines require.
+func (v *VaultClient) WriteSecret(path string, data map[string]interface{}) error {
+	info := v.kvInfoForPath(path)
+
+	writePath := path
+	payload := data
+	if info.version == 2 {
+		writePath = kvDataPath(path, info.mount)
+		payload = map[string]interface{}{"data": data}
+	}
+
+	_, err := v.client.Logical().Write(writePath, payload)
+	if err != nil {
+		return fmt.Errorf("Vault - Write Error: %s", err)
+	}
+	return nil
+}
+
+// VaultAuthConfigFromContext builds a VaultAuthConfig from the global
+// --vault-* flags, for use by both the root action and the import/export
+// subcommands.
+func VaultAuthConfigFromContext(c *cli.Context) VaultAuthConfig {
+	return VaultAuthConfig{
+		Method:   c.GlobalString("vault-auth-method"),
+		Token:    c.GlobalString("vault-token"),
+		RoleID:   c.GlobalString("vault-role-id"),
+		SecretID: c.GlobalString("vault-secret-id"),
+		K8sRole:  c.GlobalString("vault-k8s-role"),
+	}
+}
+
+// NewVaultClientFromContext builds and authenticates a VaultClient from the
+// global --vault-* and --kv-version flags.
+func NewVaultClientFromContext(c *cli.Context) (*VaultClient, error) {
+	auth, err := NewVaultAuthenticator(VaultAuthConfigFromContext(c))
+	if err != nil {
+		return nil, err
+	}
+
+	kvVersion, err := ParseKVVersion(c.GlobalString("kv-version"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewVaultClient(auth, kvVersion)
+}
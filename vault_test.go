@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestKvDataPath(t *testing.T) {
+	cases := []struct {
+		path, mount, want string
+	}{
+		{"secret/app/db", "secret", "secret/data/app/db"},
+		{"secret/app/db", "", "secret/data/app/db"},
+		{"justamount", "", "justamount/data"},
+	}
+
+	for _, c := range cases {
+		if got := kvDataPath(c.path, c.mount); got != c.want {
+			t.Errorf("kvDataPath(%q, %q) = %q, want %q", c.path, c.mount, got, c.want)
+		}
+	}
+}
+
+func TestKvMetadataPath(t *testing.T) {
+	cases := []struct {
+		path, mount, want string
+	}{
+		{"secret/app/db", "secret", "secret/metadata/app/db"},
+		{"secret/app/db", "", "secret/metadata/app/db"},
+		{"justamount", "", "justamount/metadata"},
+	}
+
+	for _, c := range cases {
+		if got := kvMetadataPath(c.path, c.mount); got != c.want {
+			t.Errorf("kvMetadataPath(%q, %q) = %q, want %q", c.path, c.mount, got, c.want)
+		}
+	}
+}